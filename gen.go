@@ -27,9 +27,26 @@ const OUTPUT_FILE_HEADER = `package mock
 `
 const OUTPUT_FILEPATH = "src/mock/endpointpattern.go"
 
+const OUTPUT_EXAMPLES_FILE_HEADER = `package mock
+
+// Code generated by gen.go; DO NOT EDIT.
+
+import (
+	"fmt"
+
+	"github.com/buger/jsonparser"
+)
+
+`
+const OUTPUT_EXAMPLES_FILEPATH = "src/mock/endpointexamples.go"
+
 type ScrapeResult struct {
 	HTTPMethod      string
 	EndpointPattern string
+	// ResponseSchema is the (possibly $ref'd) JSON schema of the first 2xx
+	// application/json response this operation documents, or nil if it
+	// doesn't document one.
+	ResponseSchema []byte
 }
 
 var debug bool
@@ -141,12 +158,15 @@ func parseOpenApiDefinition(apiDefinition []byte) <-chan ScrapeResult {
 
 				jsonparser.ObjectEach(
 					endpointDefinition,
-					func(method, _ []byte, _ jsonparser.ValueType, _ int) error {
+					func(method, methodDefinition []byte, _ jsonparser.ValueType, _ int) error {
 						httpMethod := string(method)
 
+						schema, _ := findSuccessSchema(methodDefinition)
+
 						outputChan <- ScrapeResult{
 							HTTPMethod:      httpMethod,
 							EndpointPattern: endpointPattern,
+							ResponseSchema:  schema,
 						}
 
 						return nil
@@ -164,6 +184,165 @@ func parseOpenApiDefinition(apiDefinition []byte) <-chan ScrapeResult {
 	return outputChan
 }
 
+// findSuccessSchema returns the JSON schema of the first 2xx
+// application/json response `methodDefinition` documents.
+func findSuccessSchema(methodDefinition []byte) ([]byte, bool) {
+	var schema []byte
+
+	found := false
+
+	jsonparser.ObjectEach(
+		methodDefinition,
+		func(status, responseDefinition []byte, _ jsonparser.ValueType, _ int) error {
+			if found || len(status) == 0 || status[0] != '2' {
+				return nil
+			}
+
+			s, _, _, err := jsonparser.Get(responseDefinition, "content", "application/json", "schema")
+
+			if err != nil {
+				return nil
+			}
+
+			schema = s
+			found = true
+
+			return nil
+		},
+		"responses",
+	)
+
+	return schema, found
+}
+
+// resolveSchema follows a single "$ref" pointer (e.g.
+// "#/components/schemas/simple-user") into `apiDefinition` and returns the
+// schema it points to, or `schema` unchanged if it isn't a $ref.
+func resolveSchema(apiDefinition, schema []byte) []byte {
+	ref, err := jsonparser.GetString(schema, "$ref")
+
+	if err != nil {
+		return schema
+	}
+
+	pointer := strings.Split(strings.TrimPrefix(ref, "#/"), "/")
+
+	resolved, _, _, err := jsonparser.Get(apiDefinition, pointer...)
+
+	if err != nil {
+		return schema
+	}
+
+	return resolved
+}
+
+// buildExample walks `schema` and renders a minimal JSON literal satisfying
+// it: zero values for scalars, a one-element array for "array", and every
+// documented property (resolving nested $ref, up to a sane depth) for
+// "object".
+func buildExample(apiDefinition, schema []byte, depth int) string {
+	schema = resolveSchema(apiDefinition, schema)
+
+	if depth > 8 {
+		return "null"
+	}
+
+	schemaType, _ := jsonparser.GetString(schema, "type")
+
+	switch schemaType {
+	case "object":
+		fields := []string{}
+
+		jsonparser.ObjectEach(
+			schema,
+			func(key, propSchema []byte, _ jsonparser.ValueType, _ int) error {
+				fields = append(fields, fmt.Sprintf(
+					"%q:%s",
+					string(key),
+					buildExample(apiDefinition, propSchema, depth+1),
+				))
+
+				return nil
+			},
+			"properties",
+		)
+
+		return "{" + strings.Join(fields, ",") + "}"
+	case "array":
+		items, _, _, err := jsonparser.Get(schema, "items")
+
+		if err != nil {
+			return "[]"
+		}
+
+		return "[" + buildExample(apiDefinition, items, depth+1) + "]"
+	case "integer", "number":
+		return "0"
+	case "boolean":
+		return "false"
+	case "string":
+		return `""`
+	default:
+		return "null"
+	}
+}
+
+// buildValidator emits a Validate<varName> function declaration that checks
+// every field `schema` marks "required" is present in a response body.
+func buildValidator(apiDefinition, schema []byte, varName string) string {
+	schema = resolveSchema(apiDefinition, schema)
+
+	var checks strings.Builder
+
+	jsonparser.ArrayEach(
+		schema,
+		func(value []byte, _ jsonparser.ValueType, _ int, _ error) {
+			field := string(value)
+
+			fmt.Fprintf(
+				&checks,
+				"\tif _, _, _, err := jsonparser.Get(body, %q); err != nil {\n\t\treturn fmt.Errorf(\"%s: missing required field \\\"%s\\\": %%w\", err)\n\t}\n\n",
+				field,
+				varName,
+				field,
+			)
+		},
+		"required",
+	)
+
+	return fmt.Sprintf(
+		"func Validate%s(body []byte) error {\n%s\treturn nil\n}\n",
+		varName,
+		checks.String(),
+	)
+}
+
+// formatExampleVarsAndValidator emits the generated example body variable,
+// its Validate<varName> function, and the init() line that registers both
+// against `varName`'s EndpointPattern.
+func formatExampleVarsAndValidator(apiDefinition []byte, lsr ScrapeResult) (decl string, initLine string) {
+	varName := formatToGolangVarName(log.NewNopLogger(), lsr)
+
+	example := buildExample(apiDefinition, lsr.ResponseSchema, 0)
+	validator := buildValidator(apiDefinition, lsr.ResponseSchema, varName)
+
+	decl = fmt.Sprintf(
+		"var %sExample = []byte(%q)\n\n%s\n",
+		varName,
+		example,
+		validator,
+	)
+
+	initLine = fmt.Sprintf(
+		"\texampleRegistry[%s] = mockExample{body: %sExample, validate: Validate%s}\n",
+		varName,
+		varName,
+		varName,
+	)
+
+	return decl, initLine
+}
+
 func main() {
 	flag.Parse()
 
@@ -183,9 +362,13 @@ func main() {
 	apiDefinition := fetchAPIDefinition(l)
 
 	buf := bytes.NewBuffer([]byte(OUTPUT_FILE_HEADER))
+	examplesBuf := bytes.NewBuffer([]byte(OUTPUT_EXAMPLES_FILE_HEADER))
+	initLines := bytes.NewBuffer(nil)
 
 	scrapeResultChan := parseOpenApiDefinition(apiDefinition)
 
+	varNames := []string{}
+
 	for sr := range scrapeResultChan {
 		level.Debug(l).Log(
 			"msg", fmt.Sprintf("Writing %s", sr.EndpointPattern),
@@ -197,14 +380,44 @@ func main() {
 		)
 
 		buf.WriteString(code)
+
+		varNames = append(varNames, formatToGolangVarName(l, sr))
+
+		if sr.ResponseSchema != nil {
+			decl, initLine := formatExampleVarsAndValidator(apiDefinition, sr)
+
+			examplesBuf.WriteString(decl)
+			initLines.WriteString(initLine)
+		}
+	}
+
+	buf.WriteString("\n// AllEndpointPatterns lists every EndpointPattern generated from the\n")
+	buf.WriteString("// GitHub OpenAPI description, for code that needs to resolve a concrete\n")
+	buf.WriteString("// request back to the pattern it matches (see NewRecordingClient).\n")
+	buf.WriteString("var AllEndpointPatterns = []EndpointPattern{\n")
+
+	for _, varName := range varNames {
+		buf.WriteString(fmt.Sprintf("\t%s,\n", varName))
 	}
 
+	buf.WriteString("}\n")
+
+	examplesBuf.WriteString("func init() {\n")
+	examplesBuf.Write(initLines.Bytes())
+	examplesBuf.WriteString("}\n")
+
 	ioutil.WriteFile(
 		OUTPUT_FILEPATH,
 		buf.Bytes(),
 		0755,
 	)
 
+	ioutil.WriteFile(
+		OUTPUT_EXAMPLES_FILEPATH,
+		examplesBuf.Bytes(),
+		0755,
+	)
+
 	errorsFound := false
 
 	// to catch possible format errors
@@ -213,6 +426,11 @@ func main() {
 		errorsFound = true
 	}
 
+	if err := exec.Command("gofmt", "-w", "src/mock/endpointexamples.go").Run(); err != nil {
+		level.Error(l).Log("msg", fmt.Sprintf("error executing gofmt: %s", err.Error()))
+		errorsFound = true
+	}
+
 	// to catch everything else (hopefully)
 	if err := exec.Command("go", "vet", "./...").Run(); err != nil {
 		level.Error(l).Log("msg", fmt.Sprintf("error executing go vet: %s", err.Error()))
@@ -0,0 +1,104 @@
+package mock
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// mockExample pairs a canonical example response body, generated from the
+// GitHub OpenAPI description, with a validator that checks a body has the
+// shape that schema requires.
+type mockExample struct {
+	body     []byte
+	validate func(body []byte) error
+}
+
+// exampleRegistry is populated at init time by the generated
+// endpointexamples.go, one entry per EndpointPattern gen.go could derive a
+// JSON schema example for.
+var exampleRegistry = map[EndpointPattern]mockExample{}
+
+// WithRequestMatchExample serves the example response body gen.go generated
+// for `ep` from the GitHub OpenAPI description, so a mock doesn't have to
+// hand-author a literal that can silently drift from the real schema.
+//
+// It panics if `ep` has no generated example.
+//
+// Example:
+//
+//	WithRequestMatchExample(GetUsersByUsername)
+func WithRequestMatchExample(ep EndpointPattern) MockBackendOption {
+	example, ok := exampleRegistry[ep]
+
+	if !ok {
+		panic(fmt.Sprintf("mock: no generated example for %s %s", ep.Method, ep.Pattern))
+	}
+
+	return WithRequestMatch(ep, example.body)
+}
+
+// WithResponseValidation checks every registered endpoint's response body,
+// after a test handler writes it, against the validator gen.go generated
+// from that endpoint's GitHub OpenAPI schema.
+//
+// It panics on a mismatch, so a mock whose shape has drifted from the real
+// API fails the test that relies on it instead of silently passing.
+func WithResponseValidation() MockBackendOption {
+	return func(router *mux.Router) {
+		router.Use(validateResponseMiddleware)
+	}
+}
+
+func validateResponseMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := mux.CurrentRoute(r)
+
+		if route == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		pattern, err := route.GetPathTemplate()
+
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		methods, err := route.GetMethods()
+
+		if err != nil || len(methods) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		example, ok := exampleRegistry[EndpointPattern{Pattern: pattern, Method: methods[0]}]
+
+		if !ok || example.validate == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		rec := &bufferedResponseWriter{ResponseWriter: w, buf: &bytes.Buffer{}}
+		next.ServeHTTP(rec, r)
+
+		if err := example.validate(rec.buf.Bytes()); err != nil {
+			panic(fmt.Sprintf("mock: response for %s %s failed schema validation: %s", r.Method, r.URL.Path, err))
+		}
+	})
+}
+
+// bufferedResponseWriter captures a response body as it is written so it can
+// be validated once the handler serving it is done.
+type bufferedResponseWriter struct {
+	http.ResponseWriter
+	buf *bytes.Buffer
+}
+
+func (w *bufferedResponseWriter) Write(p []byte) (int, error) {
+	w.buf.Write(p)
+	return w.ResponseWriter.Write(p)
+}
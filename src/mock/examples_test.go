@@ -0,0 +1,72 @@
+package mock
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestWithResponseValidation_PanicsOnBadBody(t *testing.T) {
+	ep := EndpointPattern{Method: http.MethodGet, Pattern: "/users/{username}"}
+
+	exampleRegistry[ep] = mockExample{
+		body: []byte(`{"login":"foobar"}`),
+		validate: func(body []byte) error {
+			if !strings.Contains(string(body), `"login"`) {
+				return fmt.Errorf("missing required field %q", "login")
+			}
+
+			return nil
+		},
+	}
+	defer delete(exampleRegistry, ep)
+
+	router := mux.NewRouter()
+	WithResponseValidation()(router)
+	router.Handle(ep.Pattern, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte(`{}`))
+	})).Methods(ep.Method)
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected WithResponseValidation to panic on a body missing the required field")
+		}
+	}()
+
+	req := httptest.NewRequest(http.MethodGet, "/users/foobar", nil)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+}
+
+func TestWithResponseValidation_PassesOnGoodBody(t *testing.T) {
+	ep := EndpointPattern{Method: http.MethodGet, Pattern: "/users/{username}"}
+
+	exampleRegistry[ep] = mockExample{
+		body: []byte(`{"login":"foobar"}`),
+		validate: func(body []byte) error {
+			if !strings.Contains(string(body), `"login"`) {
+				return fmt.Errorf("missing required field %q", "login")
+			}
+
+			return nil
+		},
+	}
+	defer delete(exampleRegistry, ep)
+
+	router := mux.NewRouter()
+	WithResponseValidation()(router)
+	router.Handle(ep.Pattern, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte(`{"login":"foobar"}`))
+	})).Methods(ep.Method)
+
+	req := httptest.NewRequest(http.MethodGet, "/users/foobar", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+}
@@ -0,0 +1,267 @@
+package mock
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/mux"
+)
+
+// RequestMatcher decides whether a *http.Request is the one a particular
+// mocked response should be served for. It lets several mocks share a single
+// EndpointPattern, discriminated by things like the request body, its query
+// string or its headers.
+type RequestMatcher interface {
+	Matches(r *http.Request) bool
+}
+
+type requestMatcherFunc func(r *http.Request) bool
+
+func (f requestMatcherFunc) Matches(r *http.Request) bool {
+	return f(r)
+}
+
+// MatchBuilder accumulates RequestMatchers for a single mocked response.
+//
+// Chain calls to narrow down which request the final Respond() handler
+// applies to, e.g.:
+//
+//	WithRequestMatchHandler(
+//		CreateIssue,
+//		MatchBodyJSON(`{"title":"bug"}`).
+//			MatchQuery("labels", "p0").
+//			MatchHeader("X-Github-Api-Version", "2022-11-28").
+//			Respond(github.Issue{...}),
+//	)
+type MatchBuilder struct {
+	matchers []RequestMatcher
+}
+
+func newMatchBuilder(m RequestMatcher) *MatchBuilder {
+	return &MatchBuilder{matchers: []RequestMatcher{m}}
+}
+
+func (b *MatchBuilder) add(m RequestMatcher) *MatchBuilder {
+	b.matchers = append(b.matchers, m)
+	return b
+}
+
+// MatchHeader requires the request's `header` to equal `value` exactly.
+func (b *MatchBuilder) MatchHeader(header, value string) *MatchBuilder {
+	return b.add(requestMatcherFunc(func(r *http.Request) bool {
+		return r.Header.Get(header) == value
+	}))
+}
+
+// MatchHeaderRegex requires the request's `header` to match `pattern`.
+func (b *MatchBuilder) MatchHeaderRegex(header, pattern string) *MatchBuilder {
+	re := regexp.MustCompile(pattern)
+
+	return b.add(requestMatcherFunc(func(r *http.Request) bool {
+		return re.MatchString(r.Header.Get(header))
+	}))
+}
+
+// MatchQuery requires the request's `key` query parameter to equal `value`
+// exactly.
+func (b *MatchBuilder) MatchQuery(key, value string) *MatchBuilder {
+	return b.add(requestMatcherFunc(func(r *http.Request) bool {
+		return r.URL.Query().Get(key) == value
+	}))
+}
+
+// MatchBody requires the request body to contain `substring`.
+func (b *MatchBuilder) MatchBody(substring string) *MatchBuilder {
+	return b.add(requestMatcherFunc(func(r *http.Request) bool {
+		return strings.Contains(string(peekBody(r)), substring)
+	}))
+}
+
+// MatchBodyRegex requires the request body to match `pattern`.
+func (b *MatchBuilder) MatchBodyRegex(pattern string) *MatchBuilder {
+	re := regexp.MustCompile(pattern)
+
+	return b.add(requestMatcherFunc(func(r *http.Request) bool {
+		return re.Match(peekBody(r))
+	}))
+}
+
+// MatchBodyJSON requires the request body to be JSON-equal to `expected`:
+// key order and numeric formatting (1 vs 1.0) are tolerated, unlike a raw
+// string or regex comparison.
+func (b *MatchBuilder) MatchBodyJSON(expected string) *MatchBuilder {
+	var want interface{}
+
+	if err := json.Unmarshal([]byte(expected), &want); err != nil {
+		panic(fmt.Sprintf("mock: MatchBodyJSON: invalid JSON: %s", err))
+	}
+
+	return b.add(requestMatcherFunc(func(r *http.Request) bool {
+		var got interface{}
+
+		if err := json.Unmarshal(peekBody(r), &got); err != nil {
+			return false
+		}
+
+		return reflect.DeepEqual(want, got)
+	}))
+}
+
+// Respond finishes the matcher chain, returning an http.Handler that writes
+// `response` whenever every matcher in the chain matches the incoming
+// request. Pass it to WithRequestMatchHandler; several of these can be
+// registered for the same EndpointPattern, and the first whose matchers all
+// pass serves the request.
+//
+// A plain value is equivalent to MockResponse{Body: value}, i.e. status 200
+// with no extra headers or delay. Pass a MockResponse directly to also
+// control the status code, headers, or an artificial delay for this intent,
+// the same way WithRequestMatch does for its FIFO:
+//
+//	MatchBodyJSON(`{"title":"bug"}`).
+//		Respond(MockResponse{
+//			Status: http.StatusUnprocessableEntity,
+//			Body:   github.ErrorResponse{Message: github.String("duplicate issue")},
+//		})
+func (b *MatchBuilder) Respond(response interface{}) http.Handler {
+	resp, ok := response.(MockResponse)
+
+	if !ok {
+		resp = MockResponse{Body: response}
+	}
+
+	return &conditionalHandler{
+		matchers: b.matchers,
+		response: resp,
+	}
+}
+
+// MatchHeader starts a matcher chain requiring the request's `header` to
+// equal `value` exactly.
+func MatchHeader(header, value string) *MatchBuilder {
+	return newMatchBuilder(requestMatcherFunc(func(r *http.Request) bool {
+		return r.Header.Get(header) == value
+	}))
+}
+
+// MatchQuery starts a matcher chain requiring the request's `key` query
+// parameter to equal `value` exactly.
+func MatchQuery(key, value string) *MatchBuilder {
+	return newMatchBuilder(requestMatcherFunc(func(r *http.Request) bool {
+		return r.URL.Query().Get(key) == value
+	}))
+}
+
+// MatchBody starts a matcher chain requiring the request body to contain
+// `substring`.
+func MatchBody(substring string) *MatchBuilder {
+	return newMatchBuilder(requestMatcherFunc(func(r *http.Request) bool {
+		return strings.Contains(string(peekBody(r)), substring)
+	}))
+}
+
+// MatchBodyRegex starts a matcher chain requiring the request body to match
+// `pattern`.
+func MatchBodyRegex(pattern string) *MatchBuilder {
+	re := regexp.MustCompile(pattern)
+
+	return newMatchBuilder(requestMatcherFunc(func(r *http.Request) bool {
+		return re.Match(peekBody(r))
+	}))
+}
+
+// MatchBodyJSON starts a matcher chain requiring the request body to be
+// JSON-equal to `expected`, tolerating key order and numeric formatting.
+func MatchBodyJSON(expected string) *MatchBuilder {
+	return (&MatchBuilder{}).MatchBodyJSON(expected)
+}
+
+// peekBody reads the request body and restores it, so it can be inspected by
+// a matcher without consuming it for the handler that ends up serving the
+// request.
+func peekBody(r *http.Request) []byte {
+	if r.Body == nil {
+		return nil
+	}
+
+	body, err := io.ReadAll(r.Body)
+
+	if err != nil {
+		return nil
+	}
+
+	r.Body.Close()
+	r.Body = io.NopCloser(strings.NewReader(string(body)))
+
+	return body
+}
+
+// conditionalHandler serves a single response once every one of its
+// matchers passes for the incoming request.
+type conditionalHandler struct {
+	matchers []RequestMatcher
+	response MockResponse
+}
+
+func (h *conditionalHandler) matchesAll(r *http.Request) bool {
+	for _, m := range h.matchers {
+		if !m.Matches(r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (h *conditionalHandler) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	writeMockResponse(w, h.response)
+}
+
+// endpointDispatcher serves one EndpointPattern backed by several
+// conditionalHandlers, dispatching to the first whose matchers all pass and
+// responding 404 when none do.
+type endpointDispatcher struct {
+	mu       sync.Mutex
+	handlers []*conditionalHandler
+}
+
+func (d *endpointDispatcher) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	d.mu.Lock()
+	handlers := append([]*conditionalHandler(nil), d.handlers...)
+	d.mu.Unlock()
+
+	for _, h := range handlers {
+		if h.matchesAll(r) {
+			h.ServeHTTP(w, r)
+			return
+		}
+	}
+
+	http.NotFound(w, r)
+}
+
+// dispatcherFor returns the endpointDispatcher registered for `ep` on
+// `router`'s shared routerState, creating one the first time `ep` is seen,
+// so multiple WithRequestMatchHandler calls for the same EndpointPattern
+// accumulate into a single route instead of overwriting one another.
+func dispatcherFor(router *mux.Router, ep EndpointPattern) (dispatcher *endpointDispatcher, alreadyRegistered bool) {
+	state := stateFor(router)
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	dispatcher, alreadyRegistered = state.dispatchers[ep]
+
+	if !alreadyRegistered {
+		dispatcher = &endpointDispatcher{}
+		state.dispatchers[ep] = dispatcher
+	}
+
+	return dispatcher, alreadyRegistered
+}
@@ -0,0 +1,96 @@
+package mock
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+// newDispatcherTestRouter registers `handlers` on `ep` the same way
+// WithRequestMatchHandler does for *conditionalHandlers, without depending
+// on NewMockedHTTPClient so this test exercises endpointDispatcher directly.
+func newDispatcherTestRouter(ep EndpointPattern, handlers ...http.Handler) *mux.Router {
+	router := mux.NewRouter()
+
+	for _, h := range handlers {
+		dispatcher, alreadyRegistered := dispatcherFor(router, ep)
+		ch := h.(*conditionalHandler)
+
+		dispatcher.mu.Lock()
+		dispatcher.handlers = append(dispatcher.handlers, ch)
+		dispatcher.mu.Unlock()
+
+		if !alreadyRegistered {
+			router.Handle(ep.Pattern, dispatcher).Methods(ep.Method)
+		}
+	}
+
+	return router
+}
+
+func TestDispatcherPrecedence(t *testing.T) {
+	ep := EndpointPattern{Method: http.MethodPost, Pattern: "/repos/{owner}/{repo}/issues"}
+
+	router := newDispatcherTestRouter(
+		ep,
+		MatchBodyJSON(`{"title":"bug"}`).Respond(map[string]string{"title": "bug"}),
+		MatchBodyJSON(`{"title":"feature"}`).Respond(map[string]string{"title": "feature"}),
+	)
+
+	req := httptest.NewRequest(http.MethodPost, "/repos/o/r/issues", strings.NewReader(`{"title":"feature"}`))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	body, _ := io.ReadAll(rec.Result().Body)
+
+	if got := string(body); !strings.Contains(got, "feature") {
+		t.Fatalf("response body = %q, want it to contain %q", got, "feature")
+	}
+}
+
+func TestDispatcherFallsBackTo404(t *testing.T) {
+	ep := EndpointPattern{Method: http.MethodPost, Pattern: "/repos/{owner}/{repo}/issues"}
+
+	router := newDispatcherTestRouter(
+		ep,
+		MatchBodyJSON(`{"title":"bug"}`).Respond(map[string]string{"title": "bug"}),
+	)
+
+	req := httptest.NewRequest(http.MethodPost, "/repos/o/r/issues", strings.NewReader(`{"title":"neither"}`))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestRespondAcceptsMockResponse(t *testing.T) {
+	ep := EndpointPattern{Method: http.MethodPost, Pattern: "/repos/{owner}/{repo}/issues"}
+
+	router := newDispatcherTestRouter(
+		ep,
+		MatchBodyJSON(`{"title":"dup"}`).Respond(MockResponse{
+			Status: http.StatusUnprocessableEntity,
+			Body:   map[string]string{"message": "duplicate issue"},
+		}),
+	)
+
+	req := httptest.NewRequest(http.MethodPost, "/repos/o/r/issues", strings.NewReader(`{"title":"dup"}`))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusUnprocessableEntity)
+	}
+
+	body, _ := io.ReadAll(rec.Result().Body)
+
+	if got := string(body); !strings.Contains(got, "duplicate issue") {
+		t.Fatalf("response body = %q, want it to contain %q", got, "duplicate issue")
+	}
+}
@@ -0,0 +1,171 @@
+package mock
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/gorilla/mux"
+)
+
+// testingT is the subset of *testing.T that AssertExpectations needs. It is
+// declared locally so this package does not have to import "testing".
+type testingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// MockRecorder observes requests dispatched by a mocked HTTP client built
+// with WithRecorder, so tests can verify not just what a mock would answer
+// but whether it was actually exercised.
+//
+// It is modeled on gock's IsDone()/Pending() style: every endpoint the
+// mocked router knows how to answer is considered "expected", and is
+// pending until at least one request reaches it.
+type MockRecorder struct {
+	mu     sync.Mutex
+	router *mux.Router
+	calls  map[EndpointPattern][]*http.Request
+}
+
+// NewMockRecorder creates an empty MockRecorder. Pass it to
+// NewMockedHTTPClient via WithRecorder to start observing requests.
+func NewMockRecorder() *MockRecorder {
+	return &MockRecorder{
+		calls: map[EndpointPattern][]*http.Request{},
+	}
+}
+
+// WithRecorder attaches `recorder` to the mocked server, so every request
+// that reaches a registered endpoint is captured against the
+// EndpointPattern it matched.
+//
+// Example:
+//
+//	recorder := mock.NewMockRecorder()
+//
+//	mockedHTTPClient := mock.NewMockedHTTPClient(
+//		mock.WithRecorder(recorder),
+//		mock.WithRequestMatch(
+//			mock.GetUsersByUsername,
+//			github.User{
+//				Name: github.String("foobar"),
+//			},
+//		),
+//	)
+//
+//	// ... exercise mockedHTTPClient ...
+//
+//	recorder.AssertExpectations(t)
+func WithRecorder(recorder *MockRecorder) MockBackendOption {
+	return func(router *mux.Router) {
+		recorder.mu.Lock()
+		recorder.router = router
+		recorder.mu.Unlock()
+
+		router.Use(recorder.middleware)
+	}
+}
+
+func (r *MockRecorder) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if route := mux.CurrentRoute(req); route != nil {
+			if pattern, err := route.GetPathTemplate(); err == nil {
+				method := req.Method
+
+				if methods, err := route.GetMethods(); err == nil && len(methods) > 0 {
+					method = methods[0]
+				}
+
+				r.record(EndpointPattern{Pattern: pattern, Method: method}, req)
+			}
+		}
+
+		next.ServeHTTP(w, req)
+	})
+}
+
+// record stores a snapshot of `req` that is safe to inspect after the
+// request has been served, since the handler that serves it is free to
+// consume its body.
+func (r *MockRecorder) record(ep EndpointPattern, req *http.Request) {
+	var body []byte
+
+	if req.Body != nil {
+		body, _ = io.ReadAll(req.Body)
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	snapshot := req.Clone(req.Context())
+	snapshot.Body = io.NopCloser(bytes.NewReader(body))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.calls[ep] = append(r.calls[ep], snapshot)
+}
+
+// Calls returns the requests that were dispatched to `ep`, in the order they
+// were received. It returns nil if `ep` was never called.
+func (r *MockRecorder) Calls(ep EndpointPattern) []*http.Request {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.calls[ep]
+}
+
+// IsDone reports whether every endpoint registered on the mocked router this
+// recorder is attached to has been called at least once.
+func (r *MockRecorder) IsDone() bool {
+	return len(r.Pending()) == 0
+}
+
+// Pending returns the registered endpoints that were never called.
+func (r *MockRecorder) Pending() []EndpointPattern {
+	pending := []EndpointPattern{}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.router == nil {
+		return pending
+	}
+
+	_ = r.router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		pathTemplate, err := route.GetPathTemplate()
+
+		if err != nil {
+			return nil
+		}
+
+		methods, err := route.GetMethods()
+
+		if err != nil {
+			return nil
+		}
+
+		for _, method := range methods {
+			ep := EndpointPattern{Pattern: pathTemplate, Method: method}
+
+			if len(r.calls[ep]) == 0 {
+				pending = append(pending, ep)
+			}
+		}
+
+		return nil
+	})
+
+	return pending
+}
+
+// AssertExpectations fails `t` if any endpoint registered on the mocked
+// router was never called.
+func (r *MockRecorder) AssertExpectations(t testingT) {
+	t.Helper()
+
+	for _, ep := range r.Pending() {
+		t.Errorf("mock: expected %s %s to be called, but it never was", ep.Method, ep.Pattern)
+	}
+}
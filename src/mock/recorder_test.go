@@ -0,0 +1,78 @@
+package mock
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+// newRecorderTestRouter registers a single GET endpoint, with `recorder`
+// attached the same way WithRecorder does, without depending on
+// NewMockedHTTPClient so this test exercises MockRecorder in isolation.
+func newRecorderTestRouter(ep EndpointPattern, recorder *MockRecorder) *mux.Router {
+	router := mux.NewRouter()
+	recorder.router = router
+	router.Use(recorder.middleware)
+	router.Handle(ep.Pattern, http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte(`{"login":"foobar"}`))
+	})).Methods(ep.Method)
+
+	return router
+}
+
+func TestMockRecorder(t *testing.T) {
+	ep := EndpointPattern{Method: http.MethodGet, Pattern: "/users/{username}"}
+	recorder := NewMockRecorder()
+	router := newRecorderTestRouter(ep, recorder)
+
+	if recorder.IsDone() {
+		t.Fatal("IsDone() = true before any request was made")
+	}
+
+	if pending := recorder.Pending(); len(pending) != 1 || pending[0] != ep {
+		t.Fatalf("Pending() = %v, want [%v]", pending, ep)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/users/foobar", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if !recorder.IsDone() {
+		t.Fatal("IsDone() = false after the only registered endpoint was called")
+	}
+
+	calls := recorder.Calls(ep)
+
+	if len(calls) != 1 {
+		t.Fatalf("Calls() = %d requests, want 1", len(calls))
+	}
+
+	if calls[0].URL.Path != "/users/foobar" {
+		t.Fatalf("Calls()[0].URL.Path = %q, want %q", calls[0].URL.Path, "/users/foobar")
+	}
+}
+
+func TestMockRecorder_AssertExpectationsFailsOnPending(t *testing.T) {
+	ep := EndpointPattern{Method: http.MethodGet, Pattern: "/users/{username}"}
+	recorder := NewMockRecorder()
+	newRecorderTestRouter(ep, recorder)
+
+	tt := &fakeT{}
+	recorder.AssertExpectations(tt)
+
+	if len(tt.errors) != 1 {
+		t.Fatalf("AssertExpectations() recorded %d errors, want 1", len(tt.errors))
+	}
+}
+
+type fakeT struct {
+	errors []string
+}
+
+func (f *fakeT) Helper() {}
+
+func (f *fakeT) Errorf(format string, args ...interface{}) {
+	f.errors = append(f.errors, format)
+}
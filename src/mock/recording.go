@@ -0,0 +1,313 @@
+package mock
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/mux"
+)
+
+// cassetteVersion is bumped whenever the on-disk cassette format changes, so
+// NewReplayClient can refuse to load a fixture an older version of this
+// package wrote in a shape it no longer understands.
+const cassetteVersion = 1
+
+// cassetteEntry is one recorded response, redacted and persisted by
+// NewRecordingClient.
+type cassetteEntry struct {
+	Method  string          `json:"method"`
+	Pattern string          `json:"pattern"`
+	Query   string          `json:"query,omitempty"`
+	Status  int             `json:"status"`
+	Headers http.Header     `json:"headers,omitempty"`
+	Body    json.RawMessage `json:"body"`
+}
+
+// cassette is the on-disk shape of one `dir/<endpoint>.json` fixture file:
+// every response NewRecordingClient has observed for a single endpoint, in
+// the order they were received.
+type cassette struct {
+	Version int             `json:"version"`
+	Entries []cassetteEntry `json:"entries"`
+}
+
+// NewRecordingClient returns an *http.Client that authenticates with `token`
+// and transparently forwards every request to the real api.github.com,
+// while writing a redacted copy of each (method, path, query, response) it
+// observes into a JSON cassette file under `dir` (one file per endpoint,
+// entries appended in call order).
+//
+// Run a test against the client this returns once, then swap it for the one
+// NewReplayClient(dir) returns to replay the same responses offline.
+func NewRecordingClient(token string, dir string) *http.Client {
+	return &http.Client{
+		Transport: &recordingRoundTripper{
+			dir: dir,
+			next: &bearerTokenRoundTripper{
+				token: token,
+				next:  http.DefaultTransport,
+			},
+		},
+	}
+}
+
+// NewReplayClient returns an *http.Client, built with NewMockedHTTPClient,
+// that serves the cassette fixtures NewRecordingClient previously wrote to
+// `dir`. Each endpoint's recorded responses are registered as a FIFO via
+// WithRequestMatch, in the order they were recorded.
+func NewReplayClient(dir string) (*http.Client, error) {
+	entries, err := os.ReadDir(dir)
+
+	if err != nil {
+		return nil, fmt.Errorf("mock: reading cassette dir %s: %w", dir, err)
+	}
+
+	options := []MockBackendOption{}
+
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+
+		c, err := loadCassette(path)
+
+		if err != nil {
+			return nil, fmt.Errorf("mock: loading cassette %s: %w", path, err)
+		}
+
+		if len(c.Entries) == 0 {
+			continue
+		}
+
+		ep := EndpointPattern{
+			Method:  c.Entries[0].Method,
+			Pattern: c.Entries[0].Pattern,
+		}
+
+		responses := make([]interface{}, 0, len(c.Entries))
+
+		for _, e := range c.Entries {
+			responses = append(responses, MockResponse{
+				Status:  e.Status,
+				Headers: e.Headers,
+				Body:    []byte(e.Body),
+			})
+		}
+
+		options = append(options, WithRequestMatch(ep, responses...))
+	}
+
+	return NewMockedHTTPClient(options...), nil
+}
+
+// bearerTokenRoundTripper sets the Authorization header NewRecordingClient
+// authenticates with, so callers just pass a plain *http.Client around.
+type bearerTokenRoundTripper struct {
+	token string
+	next  http.RoundTripper
+}
+
+func (t *bearerTokenRoundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	cloned := r.Clone(r.Context())
+	cloned.Header.Set("Authorization", "Bearer "+t.token)
+
+	return t.next.RoundTrip(cloned)
+}
+
+// recordingRoundTripper forwards every request to the live API, then writes
+// a redacted cassette entry for it before returning the real response to
+// the caller.
+type recordingRoundTripper struct {
+	dir  string
+	next http.RoundTripper
+
+	mu sync.Mutex
+}
+
+func (rt *recordingRoundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	resp, err := rt.next.RoundTrip(r)
+
+	if err != nil {
+		return resp, err
+	}
+
+	body, readErr := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	if readErr != nil {
+		return resp, nil
+	}
+
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+
+	if err := rt.appendEntry(r, resp, body); err != nil {
+		// Recording is best-effort: a failure to persist a fixture
+		// shouldn't fail the live call it was observing.
+		fmt.Fprintf(os.Stderr, "mock: failed to record %s %s: %s\n", r.Method, r.URL.Path, err)
+	}
+
+	return resp, nil
+}
+
+func (rt *recordingRoundTripper) appendEntry(r *http.Request, resp *http.Response, body []byte) error {
+	ep, ok := resolveEndpointPattern(r)
+
+	if !ok {
+		ep = EndpointPattern{Method: r.Method, Pattern: r.URL.Path}
+	}
+
+	if err := os.MkdirAll(rt.dir, 0o755); err != nil {
+		return err
+	}
+
+	path := filepath.Join(rt.dir, cassetteFilename(ep))
+
+	c, err := loadCassette(path)
+
+	if errors.Is(err, os.ErrNotExist) {
+		c = &cassette{Version: cassetteVersion}
+	} else if err != nil {
+		return err
+	}
+
+	c.Entries = append(c.Entries, cassetteEntry{
+		Method:  ep.Method,
+		Pattern: ep.Pattern,
+		Query:   redactQuery(r.URL.RawQuery),
+		Status:  resp.StatusCode,
+		Headers: redactHeaders(resp.Header),
+		Body:    json.RawMessage(redactBody(body)),
+	})
+
+	out, err := json.MarshalIndent(c, "", "\t")
+
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, out, 0o644)
+}
+
+func loadCassette(path string) (*cassette, error) {
+	data, err := os.ReadFile(path)
+
+	if errors.Is(err, os.ErrNotExist) {
+		return &cassette{Version: cassetteVersion}, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var c cassette
+
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+
+	if c.Version != cassetteVersion {
+		return nil, fmt.Errorf("cassette has version %d, this package understands version %d", c.Version, cassetteVersion)
+	}
+
+	return &c, nil
+}
+
+// endpointPatternRouter matches a concrete request against every known
+// EndpointPattern, so a recorded URL like /repos/octocat/hello/issues can be
+// written back to its template, /repos/{owner}/{repo}/issues.
+var (
+	endpointPatternRouterOnce sync.Once
+	endpointPatternRouter     *mux.Router
+)
+
+func resolveEndpointPattern(r *http.Request) (EndpointPattern, bool) {
+	endpointPatternRouterOnce.Do(func() {
+		endpointPatternRouter = mux.NewRouter()
+
+		for _, ep := range AllEndpointPatterns {
+			endpointPatternRouter.Handle(ep.Pattern, http.NotFoundHandler()).Methods(ep.Method)
+		}
+	})
+
+	var match mux.RouteMatch
+
+	if !endpointPatternRouter.Match(r, &match) || match.Route == nil {
+		return EndpointPattern{}, false
+	}
+
+	pathTemplate, err := match.Route.GetPathTemplate()
+
+	if err != nil {
+		return EndpointPattern{}, false
+	}
+
+	return EndpointPattern{Pattern: pathTemplate, Method: r.Method}, true
+}
+
+func cassetteFilename(ep EndpointPattern) string {
+	sanitizer := strings.NewReplacer("/", "_", "{", "", "}", "")
+
+	return fmt.Sprintf("%s%s.json", ep.Method, sanitizer.Replace(ep.Pattern))
+}
+
+var redactedHeaders = []string{"Authorization", "X-Github-Sso"}
+
+func redactHeaders(h http.Header) http.Header {
+	redacted := h.Clone()
+
+	for _, header := range redactedHeaders {
+		redacted.Del(header)
+	}
+
+	return redacted
+}
+
+// redactTokenPattern matches the common `"token": "..."` / `"access_token":
+// "..."` shapes GitHub responses embed credentials in.
+var redactTokenPattern = regexp.MustCompile(`(?i)("(?:token|access_token|password)"\s*:\s*")[^"]*(")`)
+
+func redactBody(body []byte) []byte {
+	return redactTokenPattern.ReplaceAll(body, []byte("${1}REDACTED${2}"))
+}
+
+// redactedQueryParams are the query string keys GitHub's older token-in-URL
+// flows (and webhook/app install callbacks) are known to carry credentials
+// in.
+var redactedQueryParams = []string{"access_token", "client_secret", "token"}
+
+// redactQuery scrubs known credential-bearing params out of a raw query
+// string before it is persisted to a cassette, mirroring redactHeaders and
+// redactBody.
+func redactQuery(rawQuery string) string {
+	if rawQuery == "" {
+		return ""
+	}
+
+	values, err := url.ParseQuery(rawQuery)
+
+	if err != nil {
+		return rawQuery
+	}
+
+	for _, param := range redactedQueryParams {
+		if _, ok := values[param]; ok {
+			values.Set(param, "REDACTED")
+		}
+	}
+
+	return values.Encode()
+}
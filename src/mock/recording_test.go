@@ -0,0 +1,111 @@
+package mock
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type fakeRoundTripper struct {
+	resp *http.Response
+}
+
+func (f *fakeRoundTripper) RoundTrip(*http.Request) (*http.Response, error) {
+	return f.resp, nil
+}
+
+// TestRecordingRoundTripper_RedactsQuerySecrets exercises the full
+// record->redact->write path a real NewRecordingClient call takes, with a
+// client_secret riding along in the query string the way GitHub's
+// token-in-query and app install callback flows carry credentials.
+func TestRecordingRoundTripper_RedactsQuerySecrets(t *testing.T) {
+	dir := t.TempDir()
+
+	rt := &recordingRoundTripper{
+		dir: dir,
+		next: &fakeRoundTripper{
+			resp: &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{},
+				Body:       io.NopCloser(strings.NewReader(`{"access_token":"shh"}`)),
+			},
+		},
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/repos/o/r/issues?client_secret=topsecret&state=open", nil)
+
+	if _, err := rt.RoundTrip(req); err != nil {
+		t.Fatalf("RoundTrip() error = %s", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("expected exactly one cassette file in %s, got %v (err %v)", dir, entries, err)
+	}
+
+	raw, err := os.ReadFile(filepath.Join(dir, entries[0].Name()))
+
+	if err != nil {
+		t.Fatalf("reading cassette: %s", err)
+	}
+
+	cassette := string(raw)
+
+	if strings.Contains(cassette, "topsecret") {
+		t.Fatalf("cassette leaked the client_secret query param:\n%s", cassette)
+	}
+
+	if strings.Contains(cassette, "shh") {
+		t.Fatalf("cassette leaked the access_token response field:\n%s", cassette)
+	}
+
+	if !strings.Contains(cassette, "state=open") {
+		t.Fatalf("cassette dropped a non-sensitive query param:\n%s", cassette)
+	}
+}
+
+func TestRedactQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  map[string]string
+	}{
+		{
+			name:  "empty query is left alone",
+			query: "",
+			want:  map[string]string{},
+		},
+		{
+			name:  "known secret params are redacted",
+			query: "access_token=a&client_secret=b&token=c&state=open",
+			want: map[string]string{
+				"access_token":  "REDACTED",
+				"client_secret": "REDACTED",
+				"token":         "REDACTED",
+				"state":         "open",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := url.ParseQuery(redactQuery(tt.query))
+
+			if err != nil {
+				t.Fatalf("parsing redacted query: %s", err)
+			}
+
+			for key, want := range tt.want {
+				if got.Get(key) != want {
+					t.Fatalf("redactQuery(%q)[%q] = %q, want %q", tt.query, key, got.Get(key), want)
+				}
+			}
+		})
+	}
+}
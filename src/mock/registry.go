@@ -0,0 +1,54 @@
+package mock
+
+import (
+	"runtime"
+	"sync"
+	"unsafe"
+
+	"github.com/gorilla/mux"
+)
+
+// routerState holds the per-router bookkeeping that several
+// MockBackendOptions need to share: the endpointDispatchers
+// WithRequestMatchHandler's matcher chains register into, and the
+// responseHandlerSlots WithRequestMatch registers, so a later call for an
+// EndpointPattern already in use replaces what mux serves instead of
+// silently registering a second, unreachable route, and so
+// WithExhaustionPolicy always configures the handler that is actually live.
+type routerState struct {
+	mu          sync.Mutex
+	dispatchers map[EndpointPattern]*endpointDispatcher
+	responses   map[EndpointPattern]*responseHandlerSlot
+}
+
+// routerStates is keyed by the *mux.Router each routerState backs. A fresh
+// mux.Router is created per NewMockedHTTPClient call, so without cleanup
+// this would grow for the life of the process, one entry per mocked client
+// a test suite ever built. stateFor attaches a finalizer the first time a
+// router is seen, so its entry is dropped once that router (and the client
+// that held it) becomes unreachable. Entries are keyed by the router's
+// address rather than the pointer itself, so the map does not keep it alive.
+var routerStates sync.Map // uintptr(unsafe.Pointer(*mux.Router)) -> *routerState
+
+func stateFor(router *mux.Router) *routerState {
+	key := uintptr(unsafe.Pointer(router))
+
+	if existing, ok := routerStates.Load(key); ok {
+		return existing.(*routerState)
+	}
+
+	state := &routerState{
+		dispatchers: map[EndpointPattern]*endpointDispatcher{},
+		responses:   map[EndpointPattern]*responseHandlerSlot{},
+	}
+
+	actual, loaded := routerStates.LoadOrStore(key, state)
+
+	if !loaded {
+		runtime.SetFinalizer(router, func(r *mux.Router) {
+			routerStates.Delete(uintptr(unsafe.Pointer(r)))
+		})
+	}
+
+	return actual.(*routerState)
+}
@@ -0,0 +1,236 @@
+package mock
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// ExhaustionPolicy controls what a mocked endpoint does once every
+// MockResponse configured for it has been served the number of times it was
+// configured for.
+type ExhaustionPolicy int
+
+const (
+	// ExhaustionPolicyPanic panics, same as the original FIFO-only
+	// behavior of WithRequestMatch. It is the default, so a test calling a
+	// mocked endpoint more often than it configured still fails loudly.
+	ExhaustionPolicyPanic ExhaustionPolicy = iota
+
+	// ExhaustionPolicyRepeatLast keeps serving the last configured
+	// response instead of panicking.
+	ExhaustionPolicyRepeatLast
+
+	// ExhaustionPolicyNotFound answers with an HTTP 404 once exhausted.
+	ExhaustionPolicyNotFound
+)
+
+// MockResponse describes a single response a mocked endpoint can give,
+// beyond just its marshaled body.
+//
+// It can be passed to WithRequestMatch alongside plain values:
+//
+//	WithRequestMatch(
+//		GetRateLimit,
+//		MockResponse{
+//			Status:  http.StatusTooManyRequests,
+//			Headers: http.Header{"Retry-After": []string{"30"}},
+//			Body:    github.RateLimitError{},
+//			Times:   1,
+//		},
+//		github.RateLimits{},
+//	)
+type MockResponse struct {
+	// Status defaults to http.StatusOK when left at zero.
+	Status int
+
+	// Body is marshaled with MustMarshal, unless it is already a []byte.
+	Body interface{}
+
+	Headers http.Header
+
+	// Delay is slept before the response is written, to simulate latency.
+	Delay time.Duration
+
+	// Times is how many consecutive calls this response is served for.
+	// 0 behaves like 1 ("once"); -1 persists it forever.
+	Times int
+}
+
+func (m MockResponse) body() []byte {
+	if b, ok := m.Body.([]byte); ok {
+		return b
+	}
+
+	return MustMarshal(m.Body)
+}
+
+func (m MockResponse) status() int {
+	if m.Status == 0 {
+		return http.StatusOK
+	}
+
+	return m.Status
+}
+
+func (m MockResponse) times() int {
+	if m.Times == 0 {
+		return 1
+	}
+
+	return m.Times
+}
+
+// mockResponseHandler serves a FIFO of MockResponse, honoring Times and
+// persistence, and falling back to its ExhaustionPolicy once they have all
+// been consumed.
+type mockResponseHandler struct {
+	mu        sync.Mutex
+	responses []MockResponse
+	served    []int
+	policy    ExhaustionPolicy
+}
+
+func (h *mockResponseHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	resp, _, exhausted := h.next()
+
+	if exhausted {
+		h.serveExhausted(w, r)
+		return
+	}
+
+	writeMockResponse(w, resp)
+}
+
+// next selects the next unexhausted MockResponse and marks it consumed in
+// the same critical section, so two concurrent callers can never both pick
+// the same `Times`-limited response.
+func (h *mockResponseHandler) next() (MockResponse, int, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for i, resp := range h.responses {
+		times := resp.times()
+
+		if times == -1 || h.served[i] < times {
+			h.served[i]++
+
+			return resp, i, false
+		}
+	}
+
+	return MockResponse{}, -1, true
+}
+
+func (h *mockResponseHandler) serveExhausted(w http.ResponseWriter, r *http.Request) {
+	switch h.policy {
+	case ExhaustionPolicyNotFound:
+		http.NotFound(w, r)
+	case ExhaustionPolicyRepeatLast:
+		h.mu.Lock()
+		last := h.responses[len(h.responses)-1]
+		h.mu.Unlock()
+
+		writeMockResponse(w, last)
+	default:
+		panic(fmt.Sprintf("mock: no more responses configured for %s %s", r.Method, r.URL.Path))
+	}
+}
+
+func writeMockResponse(w http.ResponseWriter, resp MockResponse) {
+	if resp.Delay > 0 {
+		time.Sleep(resp.Delay)
+	}
+
+	for key, values := range resp.Headers {
+		for _, value := range values {
+			w.Header().Add(key, value)
+		}
+	}
+
+	w.WriteHeader(resp.status())
+	w.Write(resp.body())
+}
+
+// responseHandlerSlot is the single, stable http.Handler registered on the
+// route for one EndpointPattern. It forwards to whichever
+// *mockResponseHandler was most recently configured for that endpoint, so a
+// later WithRequestMatch call for an EndpointPattern already in use (e.g. a
+// test overriding a shared helper's default response) replaces what gets
+// served instead of registering a second route gorilla/mux would never
+// actually dispatch to, and so WithExhaustionPolicy always configures the
+// handler that is live.
+type responseHandlerSlot struct {
+	mu      sync.Mutex
+	current *mockResponseHandler
+}
+
+func (s *responseHandlerSlot) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	h := s.current
+	s.mu.Unlock()
+
+	h.ServeHTTP(w, r)
+}
+
+func (s *responseHandlerSlot) set(h *mockResponseHandler) {
+	s.mu.Lock()
+	s.current = h
+	s.mu.Unlock()
+}
+
+func (s *responseHandlerSlot) get() *mockResponseHandler {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.current
+}
+
+// responseSlotFor returns the responseHandlerSlot registered for `ep` on
+// `router`'s shared routerState, creating one the first time `ep` is seen.
+func responseSlotFor(router *mux.Router, ep EndpointPattern) (slot *responseHandlerSlot, alreadyRegistered bool) {
+	state := stateFor(router)
+
+	state.mu.Lock()
+	defer state.mu.Unlock()
+
+	slot, alreadyRegistered = state.responses[ep]
+
+	if !alreadyRegistered {
+		slot = &responseHandlerSlot{}
+		state.responses[ep] = slot
+	}
+
+	return slot, alreadyRegistered
+}
+
+// WithExhaustionPolicy configures what `ep` does once every MockResponse
+// registered for it via WithRequestMatch has been served its configured
+// number of Times. It must be passed after the WithRequestMatch call it
+// configures, since MockBackendOptions are applied in order.
+func WithExhaustionPolicy(ep EndpointPattern, policy ExhaustionPolicy) MockBackendOption {
+	return func(router *mux.Router) {
+		state := stateFor(router)
+
+		state.mu.Lock()
+		slot, ok := state.responses[ep]
+		state.mu.Unlock()
+
+		if !ok {
+			return
+		}
+
+		handler := slot.get()
+
+		if handler == nil {
+			return
+		}
+
+		handler.mu.Lock()
+		handler.policy = policy
+		handler.mu.Unlock()
+	}
+}
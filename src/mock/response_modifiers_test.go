@@ -0,0 +1,155 @@
+package mock
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestMockResponseHandler_Times(t *testing.T) {
+	h := &mockResponseHandler{
+		responses: []MockResponse{
+			{Body: []byte(`"first"`), Times: 2},
+			{Body: []byte(`"second"`)},
+		},
+		served: []int{0, 0},
+		policy: ExhaustionPolicyNotFound,
+	}
+
+	want := []string{`"first"`, `"first"`, `"second"`, ``}
+
+	for i, w := range want {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		if w == "" {
+			if rec.Code != http.StatusNotFound {
+				t.Fatalf("call %d: status = %d, want %d once exhausted", i, rec.Code, http.StatusNotFound)
+			}
+
+			continue
+		}
+
+		if got := rec.Body.String(); got != w {
+			t.Fatalf("call %d: body = %q, want %q", i, got, w)
+		}
+	}
+}
+
+func TestMockResponseHandler_ExhaustionPolicyPanics(t *testing.T) {
+	h := &mockResponseHandler{
+		responses: []MockResponse{{Body: []byte(`"once"`)}},
+		served:    []int{0},
+		policy:    ExhaustionPolicyPanic,
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected ServeHTTP to panic once the single response was exhausted")
+		}
+	}()
+
+	h.ServeHTTP(httptest.NewRecorder(), req)
+}
+
+// TestMockResponseHandler_ConcurrentTimesIsNotOverServed exercises the race
+// between selecting a Times-limited response and marking it served: every
+// concurrent caller must see a consistent, non-overlapping slice of the
+// FIFO, never the same Times-limited entry more than its Times allows.
+func TestMockResponseHandler_ConcurrentTimesIsNotOverServed(t *testing.T) {
+	const callers = 50
+
+	h := &mockResponseHandler{
+		responses: []MockResponse{
+			{Body: []byte(`"limited"`), Times: 1},
+			{Body: []byte(`"persisted"`), Times: -1},
+		},
+		served: []int{0, 0},
+		policy: ExhaustionPolicyNotFound,
+	}
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		limitedN  int
+		persistN  int
+		notFoundN int
+	)
+
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			switch {
+			case rec.Code == http.StatusNotFound:
+				notFoundN++
+			case rec.Body.String() == `"limited"`:
+				limitedN++
+			case rec.Body.String() == `"persisted"`:
+				persistN++
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if limitedN != 1 {
+		t.Fatalf("the Times:1 response was served %d times across %d concurrent callers, want exactly 1", limitedN, callers)
+	}
+
+	if persistN != callers-1 {
+		t.Fatalf("the persisted response was served %d times, want %d", persistN, callers-1)
+	}
+
+	if notFoundN != 0 {
+		t.Fatalf("got %d unexpected 404s", notFoundN)
+	}
+}
+
+// TestWithRequestMatch_SecondCallOverridesFirst reproduces the "shared
+// helper registers a default response, then a test overrides it for one
+// ep" pattern: a second WithRequestMatch for an EndpointPattern already in
+// use must replace what gets served, and a WithExhaustionPolicy that comes
+// after it must configure that live handler rather than a first, now
+// unreachable one gorilla/mux never dispatches to.
+func TestWithRequestMatch_SecondCallOverridesFirst(t *testing.T) {
+	ep := EndpointPattern{Method: http.MethodGet, Pattern: "/repos/{owner}/{repo}"}
+
+	router := mux.NewRouter()
+
+	WithRequestMatch(ep, MockResponse{Body: []byte(`"A"`), Times: 1})(router)
+	WithRequestMatch(ep, MockResponse{Body: []byte(`"B"`), Times: 1})(router)
+	WithExhaustionPolicy(ep, ExhaustionPolicyNotFound)(router)
+
+	req := httptest.NewRequest(http.MethodGet, "/repos/o/r", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if got := rec.Body.String(); got != `"B"` {
+		t.Fatalf("first call = %q, want %q: the second WithRequestMatch should override the first", got, `"B"`)
+	}
+
+	req2 := httptest.NewRequest(http.MethodGet, "/repos/o/r", nil)
+	rec2 := httptest.NewRecorder()
+	router.ServeHTTP(rec2, req2)
+
+	if rec2.Code != http.StatusNotFound {
+		t.Fatalf("second call: status = %d, want %d: WithExhaustionPolicy should configure the handler that is actually served", rec2.Code, http.StatusNotFound)
+	}
+}
@@ -27,19 +27,51 @@ import (
 //			}))
 //		}),
 //	)
+//
+// Passing the result of a MatchBuilder chain (e.g. MatchBodyJSON(...).
+// Respond(...)) instead of a plain http.Handler is also supported: several
+// of these can be registered for the same `ep`, and the first whose
+// matchers all pass against the incoming request serves it, letting one
+// endpoint cover several intents instead of sharing a single FIFO.
 func WithRequestMatchHandler(
 	ep EndpointPattern,
 	handler http.Handler,
 ) MockBackendOption {
 	return func(router *mux.Router) {
+		if ch, ok := handler.(*conditionalHandler); ok {
+			dispatcher, alreadyRegistered := dispatcherFor(router, ep)
+
+			dispatcher.mu.Lock()
+			dispatcher.handlers = append(dispatcher.handlers, ch)
+			dispatcher.mu.Unlock()
+
+			if !alreadyRegistered {
+				router.Handle(ep.Pattern, dispatcher).Methods(ep.Method)
+			}
+
+			return
+		}
+
+		if h, ok := handler.(*mockResponseHandler); ok {
+			slot, alreadyRegistered := responseSlotFor(router, ep)
+			slot.set(h)
+
+			if !alreadyRegistered {
+				router.Handle(ep.Pattern, slot).Methods(ep.Method)
+			}
+
+			return
+		}
+
 		router.Handle(ep.Pattern, handler).Methods(ep.Method)
 	}
 }
 
-// WithRequestMatch implements a simple FIFO for requests
-// of the given `pattern`.
+// WithRequestMatch implements a simple FIFO for requests of the given
+// `pattern`.
 //
-// Once all responses have been used, it shall panic()!
+// Once all responses have been used, it panics, unless a MockResponse with
+// Times or a WithExhaustionPolicy configures otherwise.
 //
 // Example:
 //
@@ -49,23 +81,38 @@ func WithRequestMatchHandler(
 //			Name: github.String("foobar"),
 //		},
 //	)
+//
+// A plain value is equivalent to MockResponse{Body: value}. Pass a
+// MockResponse directly to also control the status code, headers, an
+// artificial delay, or how many times that response is served:
+//
+//	WithRequestMatch(
+//		GetRateLimit,
+//		MockResponse{
+//			Status:  http.StatusTooManyRequests,
+//			Headers: http.Header{"Retry-After": []string{"30"}},
+//			Body:    github.RateLimitError{},
+//		},
+//		github.RateLimits{},
+//	)
 func WithRequestMatch(
 	ep EndpointPattern,
 	responsesFIFO ...interface{},
 ) MockBackendOption {
-	responses := [][]byte{}
+	responses := make([]MockResponse, 0, len(responsesFIFO))
 
 	for _, r := range responsesFIFO {
 		switch v := r.(type) {
-		case []byte:
+		case MockResponse:
 			responses = append(responses, v)
 		default:
-			responses = append(responses, MustMarshal(r))
+			responses = append(responses, MockResponse{Body: v})
 		}
 	}
 
-	return WithRequestMatchHandler(ep, &FIFOReponseHandler{
-		Responses: responses,
+	return WithRequestMatchHandler(ep, &mockResponseHandler{
+		responses: responses,
+		served:    make([]int, len(responses)),
 	})
 }
 